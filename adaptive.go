@@ -0,0 +1,72 @@
+package circuit
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// DefaultAdaptiveK is the default aggressiveness factor used by
+// NewAdaptiveBreaker. Values between 1.5 and 2.0 are typical; lower values
+// shed load earlier, higher values tolerate more errors before shedding.
+var DefaultAdaptiveK = 2.0
+
+// AdaptiveBreaker implements the Google SRE client-side throttling algorithm
+// described in "Site Reliability Engineering", chapter 21. Rather than
+// flipping a hard open/closed switch like a threshold-based breaker, it
+// sheds an increasing fraction of requests locally as the ratio of requests
+// to accepted requests grows, allowing callers to degrade gracefully under
+// partial backend failure.
+type AdaptiveBreaker struct {
+	*window
+	K float64
+}
+
+// NewAdaptiveBreaker creates a new AdaptiveBreaker. windowTime and
+// windowBuckets are passed through to the underlying window unchanged; k is
+// the aggressiveness factor. A k of zero is replaced with DefaultAdaptiveK.
+func NewAdaptiveBreaker(windowTime time.Duration, windowBuckets int, k float64) *AdaptiveBreaker {
+	if k == 0 {
+		k = DefaultAdaptiveK
+	}
+	return &AdaptiveBreaker{window: NewWindow(windowTime, windowBuckets), K: k}
+}
+
+// Requests returns the total number of requests (failures plus successes)
+// recorded in all buckets.
+func (w *window) Requests() int64 {
+	return w.Failures() + w.Successes()
+}
+
+// Accepts returns the total number of successful requests recorded in all
+// buckets.
+func (w *window) Accepts() int64 {
+	return w.Successes()
+}
+
+// RejectionProbability returns the probability, in the range [0, 1], that
+// the next call should be rejected locally. It is computed as
+// max(0, (requests - K*accepts) / (requests + 1)), so that the breaker sheds
+// load in proportion to how far the observed accept rate has fallen behind
+// the aggressiveness factor K. Requests and accepts are read from a single
+// Snapshot rather than via separate Requests()/Accepts() calls, so the pair
+// can't straddle a concurrent bucket rotation.
+func (a *AdaptiveBreaker) RejectionProbability() float64 {
+	snap := a.Snapshot()
+	requests := float64(snap.Failures + snap.Successes)
+	accepts := float64(snap.Successes)
+
+	p := (requests - a.K*accepts) / (requests + 1)
+	return math.Max(0, p)
+}
+
+// Allow reports whether the caller should proceed with the call. If it
+// returns false, the call has already been recorded as a failure so the
+// window reflects the shed load; the caller should not call Fail() again.
+func (a *AdaptiveBreaker) Allow() bool {
+	if rand.Float64() < a.RejectionProbability() {
+		a.Fail()
+		return false
+	}
+	return true
+}
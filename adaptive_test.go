@@ -0,0 +1,50 @@
+package circuit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewAdaptiveBreaker_DefaultK(t *testing.T) {
+	a := NewAdaptiveBreaker(time.Minute, 10, 0)
+	if a.K != DefaultAdaptiveK {
+		t.Errorf("K = %v, want DefaultAdaptiveK (%v)", a.K, DefaultAdaptiveK)
+	}
+}
+
+func TestAdaptiveBreaker_RejectionProbability(t *testing.T) {
+	a := NewAdaptiveBreaker(time.Minute, 10, 2.0)
+
+	if p := a.RejectionProbability(); p != 0 {
+		t.Fatalf("RejectionProbability() on empty window = %v, want 0", p)
+	}
+
+	for i := 0; i < 10; i++ {
+		a.Success()
+	}
+	if p := a.RejectionProbability(); p != 0 {
+		t.Errorf("RejectionProbability() with requests <= K*accepts = %v, want 0", p)
+	}
+
+	for i := 0; i < 40; i++ {
+		a.Fail()
+	}
+	if p := a.RejectionProbability(); p <= 0 {
+		t.Errorf("RejectionProbability() under heavy failure = %v, want > 0", p)
+	}
+}
+
+func TestAdaptiveBreaker_AllowRecordsRejectionAsFailure(t *testing.T) {
+	a := NewAdaptiveBreaker(time.Minute, 10, 2.0)
+	for i := 0; i < 100; i++ {
+		a.Fail()
+	}
+
+	before := a.Failures()
+	if a.Allow() {
+		t.Skip("rejection probability didn't trigger a reject this run")
+	}
+	if got := a.Failures(); got != before+1 {
+		t.Errorf("Failures() after a rejected Allow() = %d, want %d", got, before+1)
+	}
+}
@@ -0,0 +1,269 @@
+package circuit
+
+import (
+	"sync"
+	"time"
+)
+
+// State is one of the three states a Breaker can be in.
+type State int
+
+const (
+	// Green is the closed state: all traffic is allowed through.
+	Green State = iota
+	// Yellow is the half-open state: a bounded number of probe requests
+	// are allowed through concurrently to test whether the backend has
+	// recovered.
+	Yellow
+	// Red is the open state: all traffic is rejected until the cooldown
+	// elapses.
+	Red
+)
+
+// String returns a human-readable name for the state, e.g. for logging.
+func (s State) String() string {
+	switch s {
+	case Green:
+		return "green"
+	case Yellow:
+		return "yellow"
+	case Red:
+		return "red"
+	default:
+		return "unknown"
+	}
+}
+
+// Breaker layers a three-state (Green/Yellow/Red) state machine on top of a
+// window's raw error-rate statistics. Unlike AdaptiveBreaker, which sheds an
+// increasing fraction of load, Breaker makes a hard allow/reject decision
+// per state, and probes the backend with a bounded, precisely-counted
+// number of requests while half-open.
+type Breaker struct {
+	*window
+
+	// ErrorThreshold is the error rate, over MinSamples or more requests,
+	// above which the breaker trips from Green to Red.
+	ErrorThreshold float64
+	// MinSamples is the minimum number of requests in the window before
+	// ErrorThreshold is evaluated, so a handful of early failures can't
+	// trip the breaker on their own.
+	MinSamples int64
+	// Cooldown is how long the breaker stays Red before allowing probe
+	// traffic in Yellow.
+	Cooldown time.Duration
+	// MaxProbes is the maximum number of probe requests allowed
+	// concurrently while Yellow.
+	MaxProbes int64
+	// ProbeSuccesses is the number of consecutive successful probes
+	// required to close the breaker from Yellow back to Green.
+	ProbeSuccesses int64
+
+	mu                        sync.Mutex
+	state                     State
+	openedAt                  time.Time
+	probesInFlight            int64
+	consecutiveProbeSuccesses int64
+	// generation is incremented every time the breaker enters Yellow, so
+	// that a probe permit can be tagged with the epoch it was issued in.
+	// completeProbe uses this to ignore completions from a Yellow cycle
+	// that has since cycled back through Red into a new one.
+	generation int64
+
+	onStateChange func(from, to State)
+	transitions   chan stateTransition
+	stop          chan struct{}
+	done          chan struct{}
+}
+
+// stateTransition is a single state change queued for delivery to
+// onStateChange.
+type stateTransition struct {
+	from, to State
+}
+
+// NewBreaker creates a Breaker backed by a new window with the given
+// windowTime and windowBuckets, starting in the Green state.
+func NewBreaker(windowTime time.Duration, windowBuckets int, errorThreshold float64, minSamples int64, cooldown time.Duration, maxProbes, probeSuccesses int64) *Breaker {
+	b := &Breaker{
+		window:         NewWindow(windowTime, windowBuckets),
+		ErrorThreshold: errorThreshold,
+		MinSamples:     minSamples,
+		Cooldown:       cooldown,
+		MaxProbes:      maxProbes,
+		ProbeSuccesses: probeSuccesses,
+		transitions:    make(chan stateTransition, 64),
+		stop:           make(chan struct{}),
+		done:           make(chan struct{}),
+	}
+	go b.deliverTransitions()
+	return b
+}
+
+// deliverTransitions drains queued state transitions one at a time and
+// invokes the registered callback, so delivery is serialized through a
+// single goroutine and always observes chronological order, even though
+// transitions themselves are pushed from under the breaker's lock. It runs
+// until Stop is called.
+func (b *Breaker) deliverTransitions() {
+	defer close(b.done)
+	for {
+		select {
+		case t := <-b.transitions:
+			b.mu.Lock()
+			cb := b.onStateChange
+			b.mu.Unlock()
+			if cb != nil {
+				cb(t.from, t.to)
+			}
+		case <-b.stop:
+			return
+		}
+	}
+}
+
+// Stop signals the background delivery goroutine to exit and waits for it
+// to do so. Transitions still queued at the time of the call may or may not
+// be delivered. Stop must only be called once.
+func (b *Breaker) Stop() {
+	close(b.stop)
+	<-b.done
+}
+
+// OnStateChange registers a callback invoked whenever the breaker
+// transitions between states, e.g. for logging or exporting metrics. It is
+// called outside of the breaker's internal lock, so it may safely call back
+// into the breaker.
+func (b *Breaker) OnStateChange(f func(from, to State)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.onStateChange = f
+}
+
+// State returns the breaker's current state.
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// Allow reports whether a call should proceed. If ok is false, the call
+// must not be made and permit is nil. If ok is true, the caller must invoke
+// permit exactly once with the outcome of the call; this both records the
+// outcome in the window and drives the breaker's state machine, including
+// capping the number of concurrent probes while Yellow.
+func (b *Breaker) Allow() (permit func(success bool), ok bool) {
+	b.mu.Lock()
+
+	switch b.state {
+	case Red:
+		if time.Since(b.openedAt) < b.Cooldown {
+			b.mu.Unlock()
+			return nil, false
+		}
+		b.setState(Yellow)
+		fallthrough
+	case Yellow:
+		if b.probesInFlight >= b.MaxProbes {
+			b.mu.Unlock()
+			return nil, false
+		}
+		b.probesInFlight++
+		gen := b.generation
+		b.mu.Unlock()
+		return func(success bool) { b.completeProbe(gen, success) }, true
+	default: // Green
+		b.mu.Unlock()
+		return b.completeRequest, true
+	}
+}
+
+// completeRequest is the permit returned while Green: it records the
+// outcome and checks whether the error rate has crossed ErrorThreshold.
+func (b *Breaker) completeRequest(success bool) {
+	if success {
+		b.Success()
+	} else {
+		b.Fail()
+	}
+
+	snap := b.Snapshot()
+	if snap.Failures+snap.Successes < b.MinSamples || snap.ErrorRate <= b.ErrorThreshold {
+		return
+	}
+
+	b.mu.Lock()
+	if b.state == Green {
+		b.setState(Red)
+	}
+	b.mu.Unlock()
+}
+
+// completeProbe is the permit returned while Yellow: it records the outcome
+// and advances the Yellow state based on consecutive probe successes or any
+// probe failure. gen is the generation the probe was issued under; if the
+// breaker has since cycled into a new Yellow epoch, the completion is stale
+// and must not perturb that epoch's probe accounting.
+func (b *Breaker) completeProbe(gen int64, success bool) {
+	if success {
+		b.Success()
+	} else {
+		b.Fail()
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if gen != b.generation {
+		return
+	}
+
+	b.probesInFlight--
+
+	if b.state != Yellow {
+		return
+	}
+
+	if !success {
+		b.consecutiveProbeSuccesses = 0
+		b.setState(Red)
+		return
+	}
+
+	b.consecutiveProbeSuccesses++
+	if b.consecutiveProbeSuccesses >= b.ProbeSuccesses {
+		b.setState(Green)
+	}
+}
+
+// setState transitions the breaker to the given state and queues the
+// transition for delivery to the state-change callback, if any. It must be
+// called with mu held, and resets the per-state bookkeeping (cooldown clock,
+// probe counters) appropriately.
+func (b *Breaker) setState(to State) {
+	from := b.state
+	if from == to {
+		return
+	}
+	b.state = to
+
+	switch to {
+	case Red:
+		b.openedAt = time.Now()
+		b.probesInFlight = 0
+		b.consecutiveProbeSuccesses = 0
+	case Yellow:
+		b.probesInFlight = 0
+		b.consecutiveProbeSuccesses = 0
+		b.generation++
+	case Green:
+		b.Reset()
+	}
+
+	select {
+	case b.transitions <- stateTransition{from, to}:
+	default:
+		// The delivery goroutine can't be blocked on; a consumer that
+		// falls behind drops transitions rather than stalling setState.
+	}
+}
@@ -0,0 +1,151 @@
+package circuit
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBreaker_TripsOnErrorThreshold(t *testing.T) {
+	b := NewBreaker(time.Minute, 10, 0.5, 4, time.Millisecond, 1, 1)
+	defer b.Stop()
+
+	for i := 0; i < 4; i++ {
+		permit, ok := b.Allow()
+		if !ok {
+			t.Fatalf("Allow() while Green returned ok=false on attempt %d", i)
+		}
+		permit(false)
+	}
+
+	if b.State() != Red {
+		t.Fatalf("State() = %v, want Red", b.State())
+	}
+}
+
+func TestBreaker_YellowCapsConcurrentProbes(t *testing.T) {
+	b := NewBreaker(time.Minute, 10, 0.5, 1, time.Millisecond, 2, 2)
+	defer b.Stop()
+
+	permit, _ := b.Allow()
+	permit(false)
+	if b.State() != Red {
+		t.Fatalf("State() = %v, want Red", b.State())
+	}
+
+	time.Sleep(2 * time.Millisecond)
+
+	p1, ok := b.Allow()
+	if !ok {
+		t.Fatal("expected first probe to be allowed")
+	}
+	p2, ok := b.Allow()
+	if !ok {
+		t.Fatal("expected second probe to be allowed")
+	}
+	if _, ok := b.Allow(); ok {
+		t.Fatal("expected third concurrent probe to be rejected while MaxProbes=2 are in flight")
+	}
+
+	p1(true)
+	p2(true)
+}
+
+// TestBreaker_StaleProbeEpochIgnored reproduces a probe permit completing
+// after the breaker has cycled through a second Red->Yellow transition: the
+// stale completion must not perturb the new epoch's probe accounting.
+func TestBreaker_StaleProbeEpochIgnored(t *testing.T) {
+	b := NewBreaker(time.Minute, 10, 0.5, 1, time.Millisecond, 2, 2)
+	defer b.Stop()
+
+	permit, _ := b.Allow()
+	permit(false)
+	if b.State() != Red {
+		t.Fatalf("State() = %v, want Red", b.State())
+	}
+	time.Sleep(2 * time.Millisecond)
+
+	// Epoch 1: two probes, one left outstanding and one that fails, cycling
+	// the breaker back to Red.
+	stale, ok := b.Allow()
+	if !ok {
+		t.Fatal("expected first probe of epoch 1 to be allowed")
+	}
+	second, ok := b.Allow()
+	if !ok {
+		t.Fatal("expected second probe of epoch 1 to be allowed")
+	}
+	second(false)
+	if b.State() != Red {
+		t.Fatalf("State() = %v, want Red after a failed probe", b.State())
+	}
+	time.Sleep(2 * time.Millisecond)
+
+	// Epoch 2: both probe slots are taken.
+	if _, ok := b.Allow(); !ok {
+		t.Fatal("expected first probe of epoch 2 to be allowed")
+	}
+	if _, ok := b.Allow(); !ok {
+		t.Fatal("expected second probe of epoch 2 to be allowed")
+	}
+	if _, ok := b.Allow(); ok {
+		t.Fatal("expected third concurrent probe in epoch 2 to be rejected")
+	}
+
+	// The stale epoch-1 permit completes late. It must not free a slot.
+	stale(true)
+
+	if _, ok := b.Allow(); ok {
+		t.Fatal("stale epoch-1 completion must not admit a third epoch-2 probe")
+	}
+}
+
+// TestBreaker_StateChangeCallbackOrder verifies that callbacks are always
+// delivered in the order the transitions happened, even though they're
+// delivered from a dedicated goroutine rather than the goroutine that made
+// the transition.
+func TestBreaker_StateChangeCallbackOrder(t *testing.T) {
+	b := NewBreaker(time.Minute, 10, 0.5, 1, time.Millisecond, 1, 1)
+	defer b.Stop()
+
+	var mu sync.Mutex
+	var seen []string
+	done := make(chan struct{})
+	b.OnStateChange(func(from, to State) {
+		mu.Lock()
+		seen = append(seen, from.String()+"->"+to.String())
+		mu.Unlock()
+		if to == Green {
+			close(done)
+		}
+	})
+
+	permit, _ := b.Allow()
+	permit(false)
+
+	time.Sleep(2 * time.Millisecond)
+
+	probe, ok := b.Allow()
+	if !ok {
+		t.Fatal("expected probe to be allowed")
+	}
+	probe(true)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for yellow->green callback")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{"green->red", "red->yellow", "yellow->green"}
+	if len(seen) != len(want) {
+		t.Fatalf("seen = %v, want %v", seen, want)
+	}
+	for i := range want {
+		if seen[i] != want[i] {
+			t.Errorf("seen[%d] = %q, want %q", i, seen[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,35 @@
+// Package gometrics adapts circuit breaker windows to the go-metrics
+// (github.com/rcrowley/go-metrics) Registry so that error rates can be
+// reported alongside a service's other go-metrics instrumentation.
+package gometrics
+
+import (
+	"fmt"
+
+	"github.com/bhardwajRahul/circuitbreaker"
+	metrics "github.com/rcrowley/go-metrics"
+)
+
+// Registry reports circuit breaker snapshots into a go-metrics Registry,
+// registering a failures gauge, successes gauge and error rate gauge per
+// breaker name. Gauges, not counters, because the window's totals roll off
+// as old buckets expire and can decrease between reports.
+type Registry struct {
+	registry metrics.Registry
+}
+
+// NewRegistry wraps an existing go-metrics Registry. Pass metrics.DefaultRegistry
+// to report into the global registry.
+func NewRegistry(registry metrics.Registry) *Registry {
+	return &Registry{registry: registry}
+}
+
+// Report implements circuit.Reporter, updating name's gauges in the wrapped
+// go-metrics Registry.
+func (r *Registry) Report(name string, failures, successes int64, errorRate float64) {
+	metrics.GetOrRegisterGauge(fmt.Sprintf("%s.failures", name), r.registry).Update(failures)
+	metrics.GetOrRegisterGauge(fmt.Sprintf("%s.successes", name), r.registry).Update(successes)
+	metrics.GetOrRegisterGaugeFloat64(fmt.Sprintf("%s.error_rate", name), r.registry).Update(errorRate)
+}
+
+var _ circuit.Reporter = (*Registry)(nil)
@@ -0,0 +1,91 @@
+package circuit
+
+import (
+	"math"
+	"math/bits"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// histogramBuckets is the number of exponentially-spaced sub-buckets
+	// used to track latency, enough to span 1µs to 10s with room to spare.
+	histogramBuckets = 32
+
+	// histogramMinShift sets the width of the bottom sub-bucket: durations
+	// are indexed by bits.Len64(ns>>histogramMinShift), so everything under
+	// roughly 1µs falls into sub-bucket 0.
+	histogramMinShift = 10
+)
+
+// observe records d in the sub-bucket for its magnitude, using
+// exponentially-spaced (power-of-two) sub-buckets so that a fixed, small
+// number of counters can cover a wide dynamic range in O(1). Durations
+// beyond the top sub-bucket are counted in overflow rather than silently
+// dropped or clamped.
+func (b *bucket) observe(d time.Duration) {
+	idx := bits.Len64(uint64(d.Nanoseconds()) >> histogramMinShift)
+	if idx >= histogramBuckets {
+		atomic.AddInt64(&b.overflow, 1)
+		return
+	}
+	atomic.AddInt64(&b.latency[idx], 1)
+}
+
+// Observe records a call's outcome and latency in the current bucket. It is
+// the primary recording call; Fail and Success remain as thin wrappers for
+// callers that don't track latency.
+func (w *window) Observe(d time.Duration, success bool) {
+	w.advance()
+	b := w.current()
+	b.observe(d)
+	if success {
+		b.Success()
+	} else {
+		b.Fail()
+	}
+}
+
+// Percentile returns the p-th percentile (0 < p <= 1) latency observed over
+// the rolling window, computed in O(histogramBuckets) time from the
+// per-bucket histograms. The result is the upper bound of the sub-bucket
+// the percentile falls into, so it is an approximation whose error is
+// bounded by the sub-bucket's width. It returns 0 if no latencies have been
+// observed.
+func (w *window) Percentile(p float64) time.Duration {
+	var latency [histogramBuckets]int64
+	var total int64
+
+	w.mu.Lock()
+	for j := range w.buckets {
+		buck := w.buckets[j].Load()
+		for i := 0; i < histogramBuckets; i++ {
+			c := atomic.LoadInt64(&buck.latency[i])
+			latency[i] += c
+			total += c
+		}
+		total += atomic.LoadInt64(&buck.overflow)
+	}
+	w.mu.Unlock()
+
+	if total == 0 {
+		return 0
+	}
+
+	target := int64(math.Ceil(p * float64(total)))
+	if target < 1 {
+		target = 1
+	}
+
+	var cumulative int64
+	for i := 0; i < histogramBuckets; i++ {
+		cumulative += latency[i]
+		if cumulative >= target {
+			return time.Duration(int64(1) << uint(i+histogramMinShift))
+		}
+	}
+
+	// The percentile falls in the overflow bucket: report the top of the
+	// histogram's range as a lower bound on the true value.
+	return time.Duration(int64(1) << uint(histogramBuckets-1+histogramMinShift))
+}
@@ -0,0 +1,45 @@
+package circuit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWindow_PercentileEmpty(t *testing.T) {
+	w := NewWindow(time.Minute, 10)
+	if p := w.Percentile(0.99); p != 0 {
+		t.Errorf("Percentile() on empty window = %v, want 0", p)
+	}
+}
+
+func TestWindow_PercentileTracksMagnitude(t *testing.T) {
+	w := NewWindow(time.Minute, 10)
+
+	for i := 0; i < 99; i++ {
+		w.Observe(time.Millisecond, true)
+	}
+	w.Observe(time.Second, true)
+
+	p50 := w.Percentile(0.5)
+	p99 := w.Percentile(0.99)
+
+	if p50 >= time.Second {
+		t.Errorf("Percentile(0.5) = %v, want well under the 1s outlier", p50)
+	}
+	if p99 < p50 {
+		t.Errorf("Percentile(0.99) = %v, want >= Percentile(0.5) = %v", p99, p50)
+	}
+}
+
+func TestWindow_ObserveRecordsOutcome(t *testing.T) {
+	w := NewWindow(time.Minute, 10)
+	w.Observe(time.Millisecond, true)
+	w.Observe(time.Millisecond, false)
+
+	if got := w.Successes(); got != 1 {
+		t.Errorf("Successes() = %d, want 1", got)
+	}
+	if got := w.Failures(); got != 1 {
+		t.Errorf("Failures() = %d, want 1", got)
+	}
+}
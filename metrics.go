@@ -0,0 +1,99 @@
+package circuit
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Reporter is implemented by metrics sinks that want to receive periodic
+// snapshots of a window's statistics. Adapters for common sinks live in the
+// prometheus and gometrics subpackages.
+type Reporter interface {
+	Report(name string, failures, successes int64, errorRate float64)
+}
+
+// Snapshot is a point-in-time view of a window's statistics: Failures,
+// Successes and ErrorRate are all derived from the same set of bucket reads,
+// so a caller computing a ratio from them can't straddle a concurrent
+// bucket rotation the way two separate calls to Failures()/Successes()
+// could.
+type Snapshot struct {
+	Failures  int64
+	Successes int64
+	ErrorRate float64
+}
+
+// Snapshot returns a point-in-time view of the window's failures,
+// successes and error rate. Like ErrorRate, it needs no lock: each bucket
+// pointer is loaded once, and a pinned pointer can't be rotated out from
+// under the read.
+func (w *window) Snapshot() Snapshot {
+	var failures, successes int64
+	for i := range w.buckets {
+		b := w.buckets[i].Load()
+		failures += atomic.LoadInt64(&b.failure)
+		successes += atomic.LoadInt64(&b.success)
+	}
+
+	total := failures + successes
+	var errorRate float64
+	if total > 0 {
+		errorRate = float64(failures) / float64(total)
+	}
+
+	return Snapshot{Failures: failures, Successes: successes, ErrorRate: errorRate}
+}
+
+// PeriodicReporter polls a window on a fixed interval and pushes its
+// Snapshot to a Reporter. It is used to feed metrics sinks that expect to be
+// pushed to, such as go-metrics registries, rather than pulled from, such as
+// Prometheus.
+type PeriodicReporter struct {
+	name     string
+	window   *window
+	reporter Reporter
+	interval time.Duration
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewPeriodicReporter creates a PeriodicReporter that reports name's
+// statistics from w to r every interval, once Start is called.
+func NewPeriodicReporter(name string, w *window, r Reporter, interval time.Duration) *PeriodicReporter {
+	return &PeriodicReporter{
+		name:     name,
+		window:   w,
+		reporter: r,
+		interval: interval,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Start begins reporting in a background goroutine. It must only be called
+// once.
+func (p *PeriodicReporter) Start() {
+	go func() {
+		defer close(p.done)
+
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				s := p.window.Snapshot()
+				p.reporter.Report(p.name, s.Failures, s.Successes, s.ErrorRate)
+			case <-p.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop signals the background goroutine to exit and waits for it to do so.
+func (p *PeriodicReporter) Stop() {
+	close(p.stop)
+	<-p.done
+}
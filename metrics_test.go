@@ -0,0 +1,82 @@
+package circuit
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWindow_Snapshot(t *testing.T) {
+	w := NewWindow(time.Minute, 10)
+	w.Success()
+	w.Success()
+	w.Fail()
+
+	s := w.Snapshot()
+	if s.Successes != 2 {
+		t.Errorf("Snapshot().Successes = %d, want 2", s.Successes)
+	}
+	if s.Failures != 1 {
+		t.Errorf("Snapshot().Failures = %d, want 1", s.Failures)
+	}
+	if want := 1.0 / 3.0; s.ErrorRate != want {
+		t.Errorf("Snapshot().ErrorRate = %v, want %v", s.ErrorRate, want)
+	}
+}
+
+// fakeReporter records the arguments of its last Report call. Report runs on
+// the PeriodicReporter's background goroutine, so access is guarded by mu.
+type fakeReporter struct {
+	mu      sync.Mutex
+	reports int
+	name    string
+	snap    Snapshot
+}
+
+func (f *fakeReporter) Report(name string, failures, successes int64, errorRate float64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.reports++
+	f.name = name
+	f.snap = Snapshot{Failures: failures, Successes: successes, ErrorRate: errorRate}
+}
+
+func (f *fakeReporter) last() (int, string, Snapshot) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.reports, f.name, f.snap
+}
+
+func TestPeriodicReporter(t *testing.T) {
+	w := NewWindow(time.Minute, 10)
+	w.Success()
+	w.Fail()
+
+	r := &fakeReporter{}
+	p := NewPeriodicReporter("test", w, r, 5*time.Millisecond)
+	p.Start()
+	defer p.Stop()
+
+	deadline := time.After(time.Second)
+	var reports int
+	var name string
+	var snap Snapshot
+	for {
+		reports, name, snap = r.last()
+		if reports > 0 {
+			break
+		}
+		select {
+		case <-deadline:
+			t.Fatal("PeriodicReporter never reported within the deadline")
+		case <-time.After(time.Millisecond):
+		}
+	}
+
+	if name != "test" {
+		t.Errorf("Report name = %q, want %q", name, "test")
+	}
+	if snap.Successes != 1 || snap.Failures != 1 {
+		t.Errorf("Report snapshot = %+v, want 1 success and 1 failure", snap)
+	}
+}
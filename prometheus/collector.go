@@ -0,0 +1,83 @@
+// Package prometheus adapts circuit breaker windows to the
+// prometheus.Collector interface so that error rates can be scraped without
+// writing per-breaker glue code.
+package prometheus
+
+import (
+	"sync"
+
+	"github.com/bhardwajRahul/circuitbreaker"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector implements prometheus.Collector over a set of named breakers.
+// Since circuit's window type is unexported, breakers are registered as a
+// snapshot function rather than the window itself; circuit.AdaptiveBreaker
+// satisfies this via its promoted Snapshot method.
+type Collector struct {
+	mu       sync.RWMutex
+	snapshot map[string]func() circuit.Snapshot
+
+	failures  *prometheus.Desc
+	successes *prometheus.Desc
+	errorRate *prometheus.Desc
+}
+
+// NewCollector creates an empty Collector. Namespace and subsystem are
+// applied to every metric name in the usual Prometheus fashion.
+func NewCollector(namespace, subsystem string) *Collector {
+	labels := []string{"breaker"}
+	return &Collector{
+		snapshot: make(map[string]func() circuit.Snapshot),
+		failures: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "failures"),
+			"Failures recorded in the breaker's current rolling window.",
+			labels, nil,
+		),
+		successes: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "successes"),
+			"Successes recorded in the breaker's current rolling window.",
+			labels, nil,
+		),
+		errorRate: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "error_rate"),
+			"Error rate of the breaker's current window.",
+			labels, nil,
+		),
+	}
+}
+
+// Add registers a breaker under name so its statistics are included on the
+// next Collect.
+func (c *Collector) Add(name string, snapshot func() circuit.Snapshot) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.snapshot[name] = snapshot
+}
+
+// Remove stops reporting the breaker registered under name.
+func (c *Collector) Remove(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.snapshot, name)
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.failures
+	ch <- c.successes
+	ch <- c.errorRate
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for name, snapshot := range c.snapshot {
+		s := snapshot()
+		ch <- prometheus.MustNewConstMetric(c.failures, prometheus.GaugeValue, float64(s.Failures), name)
+		ch <- prometheus.MustNewConstMetric(c.successes, prometheus.GaugeValue, float64(s.Successes), name)
+		ch <- prometheus.MustNewConstMetric(c.errorRate, prometheus.GaugeValue, s.ErrorRate, name)
+	}
+}
@@ -1,8 +1,8 @@
 package circuit
 
 import (
-	"container/ring"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -11,37 +11,48 @@ var (
 	DefaultWindowBuckets = 10
 )
 
-// bucket holds counts of failures and successes
+// bucket holds counts of failures and successes, plus a latency histogram.
+// All fields are accessed exclusively through sync/atomic.
 type bucket struct {
 	failure int64
 	success int64
-}
 
-// Reset resets the counts to 0
-func (b *bucket) Reset() {
-	b.failure = 0
-	b.success = 0
+	latency  [histogramBuckets]int64
+	overflow int64
 }
 
-// Fail increments the failure count
+// Fail increments the failure count.
 func (b *bucket) Fail() {
-	b.failure += 1
+	atomic.AddInt64(&b.failure, 1)
 }
 
-// Sucecss increments the success count
+// Success increments the success count.
 func (b *bucket) Success() {
-	b.success += 1
+	atomic.AddInt64(&b.success, 1)
 }
 
-// window maintains a ring of buckets and increments the failure and success
-// counts of the current bucket. Once a specified time has elapsed, it will
-// advance to the next bucket, reseting its counts. This allows the keeping of
-// rolling statistics on the counts.
+// window maintains a fixed set of buckets and increments the failure and
+// success counts of the current bucket. Once a specified time has elapsed,
+// it will advance to the next bucket. This allows the keeping of rolling
+// statistics on the counts.
+//
+// Each slot in buckets holds an atomic.Pointer[bucket] rather than a bucket
+// directly: rotating a slot out replaces its pointer with a brand new
+// bucket rather than resetting the old one's fields in place. That's what
+// keeps Fail()/Success()/Observe() lock-free, since current() just loads
+// whichever bucket the slot points to right now and adds to it — there's no
+// "reset these fields to zero" step for a concurrent add to race against.
+// A writer that loaded the old pointer a moment before a rotation swaps it
+// out still finishes its add safely; that bucket is simply retired along
+// with the generation it belonged to. idx and lastBucket are independent
+// atomics too; mu only serializes the rare rotation (and Reset) path
+// against other rotators, so it never has to be taken by the hot path.
 type window struct {
-	buckets    *ring.Ring
+	buckets    []atomic.Pointer[bucket]
 	bucketTime time.Duration
-	bucketLock sync.RWMutex
-	lastAccess time.Time
+	idx        atomic.Int64 // current bucket index
+	lastBucket atomic.Int64 // wall-clock-aligned bucket sequence number last seen
+	mu         sync.Mutex   // guards rotation/Reset; Percentile also holds it while reading
 }
 
 // NewWindow creates a new window. windowTime is the time covering the entire
@@ -49,92 +60,117 @@ type window struct {
 // An example: a 10 second window with 10 buckets will have 10 buckets covering
 // 1 second each.
 func NewWindow(windowTime time.Duration, windowBuckets int) *window {
-	buckets := ring.New(windowBuckets)
-	for i := 0; i < buckets.Len(); i++ {
-		buckets.Value = &bucket{}
-		buckets = buckets.Next()
+	bucketTime := time.Duration(windowTime.Nanoseconds() / int64(windowBuckets))
+	if bucketTime <= 0 {
+		// windowTime too small relative to windowBuckets to divide evenly;
+		// clamp rather than let bucketSeq divide by zero on every call.
+		bucketTime = time.Nanosecond
 	}
 
-	bucketTime := time.Duration(windowTime.Nanoseconds() / int64(windowBuckets))
-	return &window{buckets: buckets, bucketTime: bucketTime, lastAccess: time.Now()}
+	w := &window{
+		buckets:    make([]atomic.Pointer[bucket], windowBuckets),
+		bucketTime: bucketTime,
+	}
+	for i := range w.buckets {
+		w.buckets[i].Store(&bucket{})
+	}
+	w.lastBucket.Store(bucketSeq(time.Now(), bucketTime))
+	return w
 }
 
-// Fail records a failure in the current bucket.
-func (w *window) Fail() {
-	var b *bucket
-	w.bucketLock.Lock()
-	defer w.bucketLock.Unlock()
-
-	b = w.buckets.Value.(*bucket)
+// bucketSeq returns the sequence number of the wall-clock-aligned bucket
+// that t falls into, so that rotation is anchored to fixed boundaries
+// rather than to whenever a bucket happened to last be touched.
+func bucketSeq(t time.Time, bucketTime time.Duration) int64 {
+	return t.UnixNano() / bucketTime.Nanoseconds()
+}
 
-	if time.Since(w.lastAccess) > w.bucketTime {
-		w.buckets = w.buckets.Next()
-		b = w.buckets.Value.(*bucket)
-		b.Reset()
+// advance rotates the window to the bucket for the current wall-clock-aligned
+// bucket boundary, swapping in a fresh bucket for every slot that elapsed in
+// between (up to the full window, if the gap was large enough to stale out
+// all of it). The common case where no rotation is due only takes an atomic
+// load, so it's cheap and doesn't contend with other readers; only callers
+// that see a rotation due take mu, holding it for the whole
+// check-and-rotate sequence so that lastBucket and idx are never observed
+// out of step with one another. The first of them rotates; the rest find
+// lastBucket already current and return.
+func (w *window) advance() {
+	seq := bucketSeq(time.Now(), w.bucketTime)
+
+	if seq <= w.lastBucket.Load() {
+		return
 	}
-	w.lastAccess = time.Now()
 
-	b.Fail()
-}
+	w.mu.Lock()
+	defer w.mu.Unlock()
 
-// Success records a success in the current bucket.
-func (w *window) Success() {
-	var b *bucket
-	w.bucketLock.Lock()
-	defer w.bucketLock.Unlock()
+	last := w.lastBucket.Load()
+	if seq <= last {
+		return
+	}
 
-	b = w.buckets.Value.(*bucket)
+	n := seq - last
+	if n > int64(len(w.buckets)) {
+		n = int64(len(w.buckets))
+	}
 
-	if time.Since(w.lastAccess) > w.bucketTime {
-		w.buckets = w.buckets.Next()
-		b = w.buckets.Value.(*bucket)
-		b.Reset()
+	idx := w.idx.Load()
+	for i := int64(0); i < n; i++ {
+		idx = (idx + 1) % int64(len(w.buckets))
+		w.buckets[idx].Store(&bucket{})
 	}
-	w.lastAccess = time.Now()
+	w.idx.Store(idx)
+	w.lastBucket.Store(seq)
+}
 
-	b.Success()
+// current returns the bucket currently being written to.
+func (w *window) current() *bucket {
+	return w.buckets[w.idx.Load()].Load()
+}
+
+// Fail records a failure in the current bucket.
+func (w *window) Fail() {
+	w.advance()
+	w.current().Fail()
+}
+
+// Success records a success in the current bucket.
+func (w *window) Success() {
+	w.advance()
+	w.current().Success()
 }
 
 // Failures returns the total number of failures recorded in all buckets.
 func (w *window) Failures() int64 {
-	w.bucketLock.RLock()
-	defer w.bucketLock.RUnlock()
-
 	var failures int64
-	w.buckets.Do(func(x interface{}) {
-		b := x.(*bucket)
-		failures += b.failure
-	})
+	for i := range w.buckets {
+		failures += atomic.LoadInt64(&w.buckets[i].Load().failure)
+	}
 	return failures
 }
 
 // Successes returns the total number of successes recorded in all buckets.
 func (w *window) Successes() int64 {
-	w.bucketLock.RLock()
-	defer w.bucketLock.RUnlock()
-
 	var successes int64
-	w.buckets.Do(func(x interface{}) {
-		b := x.(*bucket)
-		successes += b.success
-	})
+	for i := range w.buckets {
+		successes += atomic.LoadInt64(&w.buckets[i].Load().success)
+	}
 	return successes
 }
 
 // ErrorRate returns the error rate calculated over all buckets, expressed as
 // a floating point number (e.g. 0.9 for 90%)
 func (w *window) ErrorRate() float64 {
-	w.bucketLock.RLock()
-	defer w.bucketLock.RUnlock()
-
 	var total int64
 	var failures int64
 
-	w.buckets.Do(func(x interface{}) {
-		b := x.(*bucket)
-		total += b.failure + b.success
-		failures += b.failure
-	})
+	for i := range w.buckets {
+		b := w.buckets[i].Load()
+		f := atomic.LoadInt64(&b.failure)
+		s := atomic.LoadInt64(&b.success)
+		total += f + s
+		failures += f
+	}
 
 	if total == 0 {
 		return 0.0
@@ -143,12 +179,12 @@ func (w *window) ErrorRate() float64 {
 	return float64(failures) / float64(total)
 }
 
-// Reset resets the count of all buckets.
+// Reset replaces every bucket with a fresh, zeroed one.
 func (w *window) Reset() {
-	w.bucketLock.Lock()
-	defer w.bucketLock.Unlock()
+	w.mu.Lock()
+	defer w.mu.Unlock()
 
-	w.buckets.Do(func(x interface{}) {
-		x.(*bucket).Reset()
-	})
+	for i := range w.buckets {
+		w.buckets[i].Store(&bucket{})
+	}
 }